@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+// RouteGuideClient wraps the generated pb.RouteGuideClient with the four
+// demo flows from the route_guide example: a unary call (PrintFeature), a
+// server-streaming call (PrintFeatures), a client-streaming call
+// (RunRecordRoute) and a bidirectional streaming call (RunRouteChat).
+type RouteGuideClient struct {
+	rg pb.RouteGuideClient
+}
+
+// NewRouteGuideClient wraps an already-dialed connection.
+func NewRouteGuideClient(cc *grpc.ClientConn) *RouteGuideClient {
+	return &RouteGuideClient{rg: pb.NewRouteGuideClient(cc)}
+}
+
+// PrintFeature gets the feature at point and logs it.
+func (c *RouteGuideClient) PrintFeature(point *pb.Point) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	feature, err := c.rg.GetFeature(ctx, point)
+	if err != nil {
+		return fmt.Errorf("GetFeature(%v): %w", point, err)
+	}
+	log.Println(feature)
+	return nil
+}
+
+// PrintFeatures lists all features within rect and logs each one.
+func (c *RouteGuideClient) PrintFeatures(rect *pb.Rectangle) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stream, err := c.rg.ListFeatures(ctx, rect)
+	if err != nil {
+		return fmt.Errorf("ListFeatures(%v): %w", rect, err)
+	}
+	for {
+		feature, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ListFeatures: %w", err)
+		}
+		log.Println(feature)
+	}
+}
+
+// RunRecordRoute sends n random points sampled from features to the server
+// and logs the resulting route summary.
+func (c *RouteGuideClient) RunRecordRoute(features []*pb.Feature, n int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	stream, err := c.rg.RecordRoute(ctx)
+	if err != nil {
+		return fmt.Errorf("RecordRoute: %w", err)
+	}
+	for i := 0; i < n; i++ {
+		if err := stream.Send(randomPoint(features)); err != nil {
+			return fmt.Errorf("RecordRoute: %w", err)
+		}
+	}
+	summary, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("RecordRoute: %w", err)
+	}
+	log.Printf("Route summary: %v", summary)
+	return nil
+}
+
+// RunRouteChat sends each of notes over a single RouteChat stream and logs
+// every note streamed back, demonstrating bidirectional streaming.
+func (c *RouteGuideClient) RunRouteChat(notes []*pb.RouteNote) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.rg.RouteChat(ctx)
+	if err != nil {
+		return fmt.Errorf("RouteChat: %w", err)
+	}
+
+	waitc := make(chan error, 1)
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err == io.EOF {
+				waitc <- nil
+				return
+			}
+			if err != nil {
+				waitc <- fmt.Errorf("RouteChat: %w", err)
+				return
+			}
+			log.Printf("Got message %q at point (%d, %d)", in.Message, in.Location.Latitude, in.Location.Longitude)
+		}
+	}()
+
+	for _, note := range notes {
+		if err := stream.Send(note); err != nil {
+			return fmt.Errorf("RouteChat: %w", err)
+		}
+	}
+	stream.CloseSend()
+	return <-waitc
+}
+
+// randomPoint returns a random point. When features is non-empty it samples
+// one of their locations, which is what the record-route demo wants so it
+// exercises known saved features; otherwise it falls back to a uniformly
+// random point in the valid lat/lng range.
+func randomPoint(features []*pb.Feature) *pb.Point {
+	if len(features) > 0 {
+		return features[rand.Intn(len(features))].Location
+	}
+	lat := (rand.Int31n(180) - 90) * 1e7
+	lng := (rand.Int31n(360) - 180) * 1e7
+	return &pb.Point{Latitude: lat, Longitude: lng}
+}
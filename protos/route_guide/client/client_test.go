@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+// fakeRouteGuideServer is a minimal pb.RouteGuideServer used only to drive
+// the client wrapper end to end, independent of the real server package.
+type fakeRouteGuideServer struct {
+	pb.UnimplementedRouteGuideServer
+}
+
+func (fakeRouteGuideServer) GetFeature(ctx context.Context, point *pb.Point) (*pb.Feature, error) {
+	return &pb.Feature{Name: "fake-feature", Location: point}, nil
+}
+
+func (fakeRouteGuideServer) ListFeatures(rect *pb.Rectangle, stream pb.RouteGuide_ListFeaturesServer) error {
+	features := []*pb.Feature{
+		{Name: "a", Location: rect.Lo},
+		{Name: "b", Location: rect.Hi},
+	}
+	for _, f := range features {
+		if err := stream.Send(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fakeRouteGuideServer) RecordRoute(stream pb.RouteGuide_RecordRouteServer) error {
+	var count int32
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&pb.RouteSummary{PointCount: count})
+		}
+		if err != nil {
+			return err
+		}
+		count++
+	}
+}
+
+func (fakeRouteGuideServer) RouteChat(stream pb.RouteGuide_RouteChatServer) error {
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(in); err != nil {
+			return err
+		}
+	}
+}
+
+func startFakeServer(t *testing.T) (*RouteGuideClient, func()) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterRouteGuideServer(srv, fakeRouteGuideServer{})
+	go srv.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial: %v", err)
+	}
+	return NewRouteGuideClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+func TestPrintFeature(t *testing.T) {
+	client, stop := startFakeServer(t)
+	defer stop()
+
+	if err := client.PrintFeature(&pb.Point{Latitude: 1, Longitude: 2}); err != nil {
+		t.Fatalf("PrintFeature: %v", err)
+	}
+}
+
+func TestPrintFeatures(t *testing.T) {
+	client, stop := startFakeServer(t)
+	defer stop()
+
+	rect := &pb.Rectangle{
+		Lo: &pb.Point{Latitude: 0, Longitude: 0},
+		Hi: &pb.Point{Latitude: 1, Longitude: 1},
+	}
+	if err := client.PrintFeatures(rect); err != nil {
+		t.Fatalf("PrintFeatures: %v", err)
+	}
+}
+
+func TestRunRecordRoute(t *testing.T) {
+	client, stop := startFakeServer(t)
+	defer stop()
+
+	if err := client.RunRecordRoute(nil, 5); err != nil {
+		t.Fatalf("RunRecordRoute: %v", err)
+	}
+}
+
+func TestRunRouteChat(t *testing.T) {
+	client, stop := startFakeServer(t)
+	defer stop()
+
+	notes := []*pb.RouteNote{
+		{Location: &pb.Point{Latitude: 0, Longitude: 1}, Message: "hello"},
+	}
+	if err := client.RunRouteChat(notes); err != nil {
+		t.Fatalf("RunRouteChat: %v", err)
+	}
+}
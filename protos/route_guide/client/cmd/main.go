@@ -0,0 +1,64 @@
+// Command client runs the four route_guide demo flows (PrintFeature,
+// PrintFeatures, RunRecordRoute, RunRouteChat) against a running
+// protos/route_guide/server, over a single gRPC connection.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+	"google.golang.org/grpc/testdata"
+
+	"xjayleex/idl/protos/route_guide/client"
+)
+
+var (
+	tls                = flag.Bool("tls", false, "If set true, uses TLS, else use TCP")
+	caFile             = flag.String("ca_file", "", "The file containing the CA root cert file. If unset, a sample root from google.golang.org/grpc/testdata is used")
+	serverHostOverride = flag.String("server_host_override", "x.test.example.com", "The server name used to verify the hostname returned by the TLS handshake")
+	serverAddr         = flag.String("server_addr", "localhost:10000", "The server address in the format of host:port")
+)
+
+func main() {
+	flag.Parse()
+	var opts []grpc.DialOption
+	if *tls {
+		if *caFile == "" {
+			*caFile = testdata.Path("ca.pem")
+		}
+		creds, err := credentials.NewClientTLSFromFile(*caFile, *serverHostOverride)
+		if err != nil {
+			log.Fatalf("Failed to create TLS credentials : %v", err)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	conn, err := grpc.Dial(*serverAddr, opts...)
+	if err != nil {
+		log.Fatalf("fail to dial: %v", err)
+	}
+	defer conn.Close()
+	rg := client.NewRouteGuideClient(conn)
+
+	log.Println("Getting feature for point (409146138, -746188906)")
+	rg.PrintFeature(&pb.Point{Latitude: 409146138, Longitude: -746188906})
+
+	log.Println("Listing features within rectangle (400000000, -750000000)-(420000000, -730000000)")
+	rg.PrintFeatures(&pb.Rectangle{
+		Lo: &pb.Point{Latitude: 400000000, Longitude: -750000000},
+		Hi: &pb.Point{Latitude: 420000000, Longitude: -730000000},
+	})
+
+	log.Println("Traversing 10 random points")
+	rg.RunRecordRoute(nil, 10)
+
+	log.Println("Routechat")
+	rg.RunRouteChat([]*pb.RouteNote{
+		{Location: &pb.Point{Latitude: 0, Longitude: 1}, Message: "First message"},
+		{Location: &pb.Point{Latitude: 0, Longitude: 2}, Message: "Second message"},
+	})
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"log"
+	"sync"
+
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+// chatSubscriberBuffer bounds how many notes a single RouteChat subscriber
+// can have queued before Publish starts dropping for that subscriber.
+const chatSubscriberBuffer = 16
+
+// pointKey is a comparable map key for a *pb.Point, used instead of string
+// serialization so two equal points always collide regardless of formatting.
+type pointKey struct {
+	lat, lng int32
+}
+
+func keyFor(point *pb.Point) pointKey {
+	return pointKey{lat: point.Latitude, lng: point.Longitude}
+}
+
+// chatHub is a pub/sub broker for RouteNotes, keyed by the location they were
+// sent at. It replaces the old per-call map: every RouteChat call subscribes
+// through the same hub, so notes sent by one client are published to every
+// other client chatting at that location.
+type chatHub struct {
+	mu          sync.Mutex
+	store       Store
+	history     map[pointKey][]*pb.RouteNote
+	subscribers map[pointKey]map[int]chan *pb.RouteNote
+	nextID      int
+}
+
+// newChatHub creates a hub backed by store. store may be nil, in which case
+// notes only live for the lifetime of the process, as before. When non-nil,
+// any route notes persisted by a previous run are loaded as initial history.
+func newChatHub(store Store) *chatHub {
+	h := &chatHub{
+		store:       store,
+		history:     make(map[pointKey][]*pb.RouteNote),
+		subscribers: make(map[pointKey]map[int]chan *pb.RouteNote),
+	}
+	if store != nil {
+		notes, err := store.LoadRouteNotes()
+		if err != nil {
+			log.Printf("chatHub: failed to load persisted route notes: %v", err)
+		} else {
+			h.history = notes
+		}
+	}
+	return h
+}
+
+// Subscribe registers a new listener for notes published at point. The
+// returned cancel func must be called exactly once to unregister it; it is
+// safe to call concurrently with Publish.
+func (h *chatHub) Subscribe(point *pb.Point) (<-chan *pb.RouteNote, func()) {
+	_, ch, cancel := h.SubscribeWithHistory(point)
+	return ch, cancel
+}
+
+// SubscribeWithHistory registers a new listener for point, same as
+// Subscribe, and also returns the notes already published at point — both
+// taken under the same lock. Callers that want to replay history and then
+// stream live notes without missing or duplicating one must use this
+// instead of composing History and Subscribe: a Publish landing between
+// those two independently-locked calls would otherwise show up in both the
+// history snapshot and on the just-registered channel.
+func (h *chatHub) SubscribeWithHistory(point *pb.Point) ([]*pb.RouteNote, <-chan *pb.RouteNote, func()) {
+	key := keyFor(point)
+	ch := make(chan *pb.RouteNote, chatSubscriberBuffer)
+
+	h.mu.Lock()
+	history := make([]*pb.RouteNote, len(h.history[key]))
+	copy(history, h.history[key])
+
+	id := h.nextID
+	h.nextID++
+	if h.subscribers[key] == nil {
+		h.subscribers[key] = make(map[int]chan *pb.RouteNote)
+	}
+	h.subscribers[key][id] = ch
+	h.mu.Unlock()
+
+	cancel := func() {
+		h.mu.Lock()
+		delete(h.subscribers[key], id)
+		if len(h.subscribers[key]) == 0 {
+			delete(h.subscribers, key)
+		}
+		h.mu.Unlock()
+	}
+	return history, ch, cancel
+}
+
+// History returns the notes previously published at point, oldest first.
+func (h *chatHub) History(point *pb.Point) []*pb.RouteNote {
+	key := keyFor(point)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]*pb.RouteNote, len(h.history[key]))
+	copy(out, h.history[key])
+	return out
+}
+
+// Publish appends note to the history for its location and fans it out to
+// every current subscriber of that location. Subscribers whose buffer is
+// full are skipped rather than blocking the publisher (slow-consumer drop
+// policy) — RouteChat is a best-effort chat feed, not a delivery guarantee.
+func (h *chatHub) Publish(note *pb.RouteNote) {
+	key := keyFor(note.Location)
+
+	h.mu.Lock()
+	h.history[key] = append(h.history[key], note)
+	store := h.store
+	subs := make([]chan *pb.RouteNote, 0, len(h.subscribers[key]))
+	for _, ch := range h.subscribers[key] {
+		subs = append(subs, ch)
+	}
+	h.mu.Unlock()
+
+	if store != nil {
+		if err := store.AppendRouteNote(note); err != nil {
+			log.Printf("chatHub: failed to persist route note: %v", err)
+		}
+	}
+
+	for _, ch := range subs {
+		select {
+		case ch <- note:
+		default:
+		}
+	}
+}
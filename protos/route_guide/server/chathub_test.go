@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+func point(lat, lng int32) *pb.Point {
+	return &pb.Point{Latitude: lat, Longitude: lng}
+}
+
+func TestChatHubFanOut(t *testing.T) {
+	hub := newChatHub(nil)
+	p := point(1, 2)
+
+	chA, cancelA := hub.Subscribe(p)
+	defer cancelA()
+	chB, cancelB := hub.Subscribe(p)
+	defer cancelB()
+
+	note := &pb.RouteNote{Location: p, Message: "hello"}
+	hub.Publish(note)
+
+	for _, ch := range []<-chan *pb.RouteNote{chA, chB} {
+		select {
+		case got := <-ch:
+			if got.Message != "hello" {
+				t.Fatalf("got message %q, want %q", got.Message, "hello")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for published note")
+		}
+	}
+}
+
+func TestChatHubHistoryReplay(t *testing.T) {
+	hub := newChatHub(nil)
+	p := point(3, 4)
+
+	hub.Publish(&pb.RouteNote{Location: p, Message: "first"})
+	hub.Publish(&pb.RouteNote{Location: p, Message: "second"})
+
+	history := hub.History(p)
+	if len(history) != 2 {
+		t.Fatalf("got %d notes, want 2", len(history))
+	}
+	if history[0].Message != "first" || history[1].Message != "second" {
+		t.Fatalf("unexpected history order: %+v", history)
+	}
+}
+
+func TestChatHubUnsubscribeStopsDelivery(t *testing.T) {
+	hub := newChatHub(nil)
+	p := point(5, 6)
+
+	ch, cancel := hub.Subscribe(p)
+	cancel()
+
+	hub.Publish(&pb.RouteNote{Location: p, Message: "late"})
+
+	select {
+	case note, ok := <-ch:
+		if ok {
+			t.Fatalf("unexpected note delivered after unsubscribe: %+v", note)
+		}
+	case <-time.After(50 * time.Millisecond):
+		// No delivery, as expected.
+	}
+}
+
+func TestChatHubSubscribeWithHistoryIsAtomic(t *testing.T) {
+	hub := newChatHub(nil)
+	p := point(9, 9)
+
+	const total = 50
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < total; i++ {
+			hub.Publish(&pb.RouteNote{Location: p, Message: fmt.Sprintf("n%d", i)})
+		}
+	}()
+
+	// Give the publisher goroutine a head start so some publishes race
+	// with the subscribe below, rather than all landing safely before it.
+	time.Sleep(time.Millisecond)
+	history, ch, cancel := hub.SubscribeWithHistory(p)
+	defer cancel()
+
+	seen := make(map[string]bool, total)
+	for _, n := range history {
+		if seen[n.Message] {
+			t.Fatalf("duplicate %q within history alone", n.Message)
+		}
+		seen[n.Message] = true
+	}
+
+	wg.Wait()
+drain:
+	for {
+		select {
+		case n := <-ch:
+			if seen[n.Message] {
+				t.Fatalf("note %q delivered both in history and on the live channel", n.Message)
+			}
+			seen[n.Message] = true
+		case <-time.After(50 * time.Millisecond):
+			break drain
+		}
+	}
+}
+
+func TestChatHubSlowConsumerDropsRatherThanBlocks(t *testing.T) {
+	hub := newChatHub(nil)
+	p := point(7, 8)
+
+	_, cancel := hub.Subscribe(p)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < chatSubscriberBuffer*2; i++ {
+			hub.Publish(&pb.RouteNote{Location: p, Message: "note"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow consumer instead of dropping")
+	}
+}
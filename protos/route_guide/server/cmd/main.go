@@ -0,0 +1,56 @@
+// Command server runs the route_guide gRPC server over TCP or TLS, backed
+// by a JSON-file or BoltDB feature/route-note store.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+	"google.golang.org/grpc/testdata"
+
+	"xjayleex/idl/protos/route_guide/server"
+)
+
+var (
+	tls          = flag.Bool("tls", false, "If set true, uses TLS, else use TCP")
+	certFile     = flag.String("cert_file", "", "TLS cert file. If unset, a sample certificate from google.golang.org/grpc/testdata is used")
+	keyFile      = flag.String("key_file", "", "TLS key file. If unset, a sample key from google.golang.org/grpc/testdata is used")
+	jsonDBFile   = flag.String("json_db_file", "", "")
+	port         = flag.Int("port", 10000, "Server port")
+	storeBackend = flag.String("store_backend", "json", "Feature/route-note store backend to use: \"json\" or \"bolt\"")
+	boltDBFile   = flag.String("bolt_db_file", "route_guide.db", "Path to the BoltDB file used when -store_backend=bolt")
+)
+
+func main() {
+	flag.Parse()
+	lis, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", *port))
+	if err != nil {
+		log.Fatalf("failed to listen : %v", err)
+	}
+	var opts []grpc.ServerOption
+	if *tls {
+		if *certFile == "" {
+			*certFile = testdata.Path("server1.pem")
+		}
+		if *keyFile == "" {
+			*keyFile = testdata.Path("server1.key")
+		}
+		creds, err := credentials.NewServerTLSFromFile(*certFile, *keyFile)
+		if err != nil {
+			log.Fatalf("Failed to generate credentials : %v", err)
+		}
+		opts = []grpc.ServerOption{grpc.Creds(creds)}
+	}
+	grpcServer := grpc.NewServer(opts...)
+	store, err := server.NewStore(*storeBackend, *jsonDBFile, *boltDBFile)
+	if err != nil {
+		log.Fatalf("failed to open store: %v", err)
+	}
+	pb.RegisterRouteGuideServer(grpcServer, server.NewServer(store))
+	grpcServer.Serve(lis)
+}
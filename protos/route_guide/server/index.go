@@ -0,0 +1,314 @@
+package server
+
+import (
+	"github.com/golang/protobuf/proto"
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+// FeatureIndex is the pluggable lookup/range strategy used by routeGuideServer.
+// Coordinates follow the wire format: latitude/longitude scaled by 1e7.
+type FeatureIndex interface {
+	Lookup(*pb.Point) *pb.Feature
+	Range(*pb.Rectangle) []*pb.Feature
+}
+
+const defaultGeohashPrecision = 7
+
+// geohashIndex buckets features by a base32 geohash of their location, which
+// makes GetFeature's exact-point lookup O(1) on average instead of scanning
+// every saved feature. Range queries fall back to a linear scan since a
+// single geohash bucket says nothing about neighbouring cells.
+type geohashIndex struct {
+	precision int
+	buckets   map[string][]*pb.Feature
+	all       []*pb.Feature
+}
+
+// GeohashOption configures a geohashIndex.
+type GeohashOption func(*geohashIndex)
+
+// WithGeohashPrecision sets the number of base32 characters used per bucket
+// key. Higher precision means smaller, more selective buckets.
+func WithGeohashPrecision(precision int) GeohashOption {
+	return func(idx *geohashIndex) {
+		if precision > 0 {
+			idx.precision = precision
+		}
+	}
+}
+
+func newGeohashIndex(features []*pb.Feature, opts ...GeohashOption) *geohashIndex {
+	idx := &geohashIndex{
+		precision: defaultGeohashPrecision,
+		buckets:   make(map[string][]*pb.Feature),
+	}
+	for _, opt := range opts {
+		opt(idx)
+	}
+	for _, f := range features {
+		key := geohashEncode(f.Location, idx.precision)
+		idx.buckets[key] = append(idx.buckets[key], f)
+	}
+	idx.all = features
+	return idx
+}
+
+func (idx *geohashIndex) Lookup(point *pb.Point) *pb.Feature {
+	key := geohashEncode(point, idx.precision)
+	for _, f := range idx.buckets[key] {
+		if proto.Equal(f.Location, point) {
+			return f
+		}
+	}
+	return nil
+}
+
+func (idx *geohashIndex) Range(rect *pb.Rectangle) []*pb.Feature {
+	var out []*pb.Feature
+	for _, f := range idx.all {
+		if inRange(f.Location, rect) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohashEncode produces a standard base32 geohash for a point whose
+// lat/lng are stored as degrees * 1e7, truncated to precision characters.
+func geohashEncode(point *pb.Point, precision int) string {
+	lat := float64(point.Latitude) / 1e7
+	lng := float64(point.Longitude) / 1e7
+
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	var bits []byte
+	evenBit := true
+	for len(bits) < precision*5 {
+		if evenBit {
+			mid := (lngRange[0] + lngRange[1]) / 2
+			if lng >= mid {
+				bits = append(bits, 1)
+				lngRange[0] = mid
+			} else {
+				bits = append(bits, 0)
+				lngRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				bits = append(bits, 1)
+				latRange[0] = mid
+			} else {
+				bits = append(bits, 0)
+				latRange[1] = mid
+			}
+		}
+		evenBit = !evenBit
+	}
+
+	out := make([]byte, 0, precision)
+	for i := 0; i < len(bits); i += 5 {
+		var idx int
+		for j := 0; j < 5; j++ {
+			idx = idx << 1
+			if i+j < len(bits) {
+				idx |= int(bits[i+j])
+			}
+		}
+		out = append(out, geohashBase32[idx])
+	}
+	return string(out)
+}
+
+// rect is an axis-aligned bounding box over the int32*1e7 coordinate space
+// used throughout the service.
+type rect struct {
+	loLat, loLng, hiLat, hiLng int32
+}
+
+func rectForPoint(p *pb.Point) rect {
+	return rect{loLat: p.Latitude, loLng: p.Longitude, hiLat: p.Latitude, hiLng: p.Longitude}
+}
+
+func rectForRectangle(r *pb.Rectangle) rect {
+	out := rect{loLat: r.Lo.Latitude, loLng: r.Lo.Longitude, hiLat: r.Hi.Latitude, hiLng: r.Hi.Longitude}
+	if out.loLat > out.hiLat {
+		out.loLat, out.hiLat = out.hiLat, out.loLat
+	}
+	if out.loLng > out.hiLng {
+		out.loLng, out.hiLng = out.hiLng, out.loLng
+	}
+	return out
+}
+
+func (a rect) expand(b rect) rect {
+	out := a
+	if b.loLat < out.loLat {
+		out.loLat = b.loLat
+	}
+	if b.loLng < out.loLng {
+		out.loLng = b.loLng
+	}
+	if b.hiLat > out.hiLat {
+		out.hiLat = b.hiLat
+	}
+	if b.hiLng > out.hiLng {
+		out.hiLng = b.hiLng
+	}
+	return out
+}
+
+func (a rect) overlaps(b rect) bool {
+	return a.loLat <= b.hiLat && a.hiLat >= b.loLat &&
+		a.loLng <= b.hiLng && a.hiLng >= b.loLng
+}
+
+func (a rect) area() int64 {
+	return int64(a.hiLat-a.loLat) * int64(a.hiLng-a.loLng)
+}
+
+const rtreeMaxEntries = 8
+
+// rtreeEntry is a leaf entry: a feature together with the degenerate
+// bounding box of its location.
+type rtreeEntry struct {
+	box     rect
+	feature *pb.Feature
+}
+
+// rtreeNode is either an internal node (children set) or a leaf (entries set).
+type rtreeNode struct {
+	box      rect
+	children []*rtreeNode
+	entries  []rtreeEntry
+}
+
+// rtree is a small in-memory R-tree used to answer ListFeatures range
+// queries without scanning every saved feature. Insertion descends into
+// the child whose box grows least at each level, and a linear split runs
+// when a leaf overflows rtreeMaxEntries, which is simple and good enough
+// for the feature-set sizes this demo server deals with.
+type rtree struct {
+	root *rtreeNode
+}
+
+func newRTree(features []*pb.Feature) *rtree {
+	t := &rtree{root: &rtreeNode{}}
+	for _, f := range features {
+		t.insert(rtreeEntry{box: rectForPoint(f.Location), feature: f})
+	}
+	return t
+}
+
+func (t *rtree) insert(e rtreeEntry) {
+	if t.root == nil {
+		t.root = &rtreeNode{}
+	}
+	path := t.chooseLeafPath(t.root, e.box)
+	leaf := path[len(path)-1]
+	if len(leaf.entries) == 0 && len(leaf.children) == 0 {
+		leaf.box = e.box
+	} else {
+		leaf.box = leaf.box.expand(e.box)
+	}
+	leaf.entries = append(leaf.entries, e)
+
+	// Every ancestor on the path down to leaf bounds e too now, not just
+	// leaf and the root — otherwise a node split at an earlier insert keeps
+	// a stale, too-small box and search()'s overlap pruning can skip a
+	// subtree that actually contains a match.
+	for i := len(path) - 2; i >= 0; i-- {
+		path[i].box = path[i].box.expand(e.box)
+	}
+
+	if len(leaf.entries) > rtreeMaxEntries {
+		t.split(leaf)
+	}
+}
+
+// chooseLeafPath returns the path from n down to the leaf e.box should be
+// inserted into, inclusive of both ends, descending at each level into
+// whichever child's box would grow the least to contain box.
+func (t *rtree) chooseLeafPath(n *rtreeNode, box rect) []*rtreeNode {
+	if len(n.children) == 0 {
+		return []*rtreeNode{n}
+	}
+	best := n.children[0]
+	bestGrowth := best.box.expand(box).area() - best.box.area()
+	for _, c := range n.children[1:] {
+		growth := c.box.expand(box).area() - c.box.area()
+		if growth < bestGrowth {
+			best = c
+			bestGrowth = growth
+		}
+	}
+	return append([]*rtreeNode{n}, t.chooseLeafPath(best, box)...)
+}
+
+// split performs a linear-cost split of an overflowing leaf into two new
+// leaves, turning leaf itself into the internal node that holds them (this
+// applies to the root as well; no new root node is created).
+func (t *rtree) split(leaf *rtreeNode) {
+	entries := leaf.entries
+	a, b := &rtreeNode{entries: []rtreeEntry{entries[0]}}, &rtreeNode{entries: []rtreeEntry{entries[1]}}
+	a.box, b.box = entries[0].box, entries[1].box
+	for _, e := range entries[2:] {
+		growthA := a.box.expand(e.box).area() - a.box.area()
+		growthB := b.box.expand(e.box).area() - b.box.area()
+		if growthA <= growthB {
+			a.entries = append(a.entries, e)
+			a.box = a.box.expand(e.box)
+		} else {
+			b.entries = append(b.entries, e)
+			b.box = b.box.expand(e.box)
+		}
+	}
+
+	// leaf is reused in place as the new internal node so the caller (the
+	// parent node holding this *rtreeNode, or t.root itself) doesn't need
+	// to be rewired.
+	*leaf = rtreeNode{children: []*rtreeNode{a, b}, box: a.box.expand(b.box)}
+}
+
+func (t *rtree) Range(r *pb.Rectangle) []*pb.Feature {
+	if t.root == nil {
+		return nil
+	}
+	q := rectForRectangle(r)
+	var out []*pb.Feature
+	t.search(t.root, q, &out)
+	return out
+}
+
+func (t *rtree) search(n *rtreeNode, q rect, out *[]*pb.Feature) {
+	if !n.box.overlaps(q) && (len(n.children) > 0 || len(n.entries) > 0) {
+		return
+	}
+	for _, c := range n.children {
+		if c.box.overlaps(q) {
+			t.search(c, q, out)
+		}
+	}
+	for _, e := range n.entries {
+		if e.box.overlaps(q) {
+			*out = append(*out, e.feature)
+		}
+	}
+}
+
+func (t *rtree) Lookup(point *pb.Point) *pb.Feature {
+	box := rectForPoint(point)
+	var candidates []*pb.Feature
+	if t.root != nil {
+		t.search(t.root, box, &candidates)
+	}
+	for _, f := range candidates {
+		if proto.Equal(f.Location, point) {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"testing"
+
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+func featureAt(name string, lat, lng int32) *pb.Feature {
+	return &pb.Feature{Name: name, Location: &pb.Point{Latitude: lat, Longitude: lng}}
+}
+
+func TestGeohashIndexLookup(t *testing.T) {
+	features := []*pb.Feature{
+		featureAt("a", 100000000, 200000000),
+		featureAt("b", -300000000, -400000000),
+	}
+	idx := newGeohashIndex(features)
+
+	if got := idx.Lookup(&pb.Point{Latitude: 100000000, Longitude: 200000000}); got == nil || got.Name != "a" {
+		t.Fatalf("Lookup(a) = %v, want feature a", got)
+	}
+	if got := idx.Lookup(&pb.Point{Latitude: -300000000, Longitude: -400000000}); got == nil || got.Name != "b" {
+		t.Fatalf("Lookup(b) = %v, want feature b", got)
+	}
+	if got := idx.Lookup(&pb.Point{Latitude: 1, Longitude: 1}); got != nil {
+		t.Fatalf("Lookup(unknown) = %v, want nil", got)
+	}
+}
+
+func TestGeohashIndexRangeIncludesBoundary(t *testing.T) {
+	features := []*pb.Feature{
+		featureAt("corner", 0, 0),
+		featureAt("outside", 50000000, 50000000),
+	}
+	idx := newGeohashIndex(features)
+
+	rect := &pb.Rectangle{
+		Lo: &pb.Point{Latitude: 0, Longitude: 0},
+		Hi: &pb.Point{Latitude: 10000000, Longitude: 10000000},
+	}
+	got := idx.Range(rect)
+	if len(got) != 1 || got[0].Name != "corner" {
+		t.Fatalf("Range = %v, want only the corner feature", got)
+	}
+}
+
+func TestGeohashEncodeNegativeCoordinates(t *testing.T) {
+	p1 := &pb.Point{Latitude: -419146138, Longitude: -746188906}
+	p2 := &pb.Point{Latitude: -419146138, Longitude: -746188906}
+	if geohashEncode(p1, defaultGeohashPrecision) != geohashEncode(p2, defaultGeohashPrecision) {
+		t.Fatal("geohashEncode is not deterministic for identical negative points")
+	}
+
+	far := &pb.Point{Latitude: 419146138, Longitude: 746188906}
+	if geohashEncode(p1, defaultGeohashPrecision) == geohashEncode(far, defaultGeohashPrecision) {
+		t.Fatal("geohashEncode collided for points on opposite sides of the globe")
+	}
+}
+
+func TestRTreeLookupAndRangeAcrossSplits(t *testing.T) {
+	var features []*pb.Feature
+	for i := int32(0); i < 50; i++ {
+		features = append(features, featureAt("f", i*1000, i*2000))
+	}
+	tree := newRTree(features)
+
+	for _, f := range features {
+		if got := tree.Lookup(f.Location); got == nil {
+			t.Fatalf("Lookup(%v) = nil, want a match after tree splits", f.Location)
+		}
+	}
+	if got := tree.Lookup(&pb.Point{Latitude: -1, Longitude: -1}); got != nil {
+		t.Fatalf("Lookup(unknown) = %v, want nil", got)
+	}
+
+	rect := &pb.Rectangle{
+		Lo: &pb.Point{Latitude: 0, Longitude: 0},
+		Hi: &pb.Point{Latitude: 10000, Longitude: 20000},
+	}
+	got := tree.Range(rect)
+	if len(got) != 11 {
+		t.Fatalf("Range returned %d features, want 11", len(got))
+	}
+}
+
+func TestRTreeRangeEmpty(t *testing.T) {
+	tree := newRTree(nil)
+	rect := &pb.Rectangle{
+		Lo: &pb.Point{Latitude: -1, Longitude: -1},
+		Hi: &pb.Point{Latitude: 1, Longitude: 1},
+	}
+	if got := tree.Range(rect); len(got) != 0 {
+		t.Fatalf("Range on empty tree = %v, want empty", got)
+	}
+	if got := tree.Lookup(&pb.Point{Latitude: 0, Longitude: 0}); got != nil {
+		t.Fatalf("Lookup on empty tree = %v, want nil", got)
+	}
+}
@@ -2,31 +2,26 @@ package server
 
 import (
 	"context"
-	"encoding/json"
-	"flag"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	pb "google.golang.org/grpc/examples/route_guide/routeguide"
-	"google.golang.org/grpc"
 	"io"
-	"io/ioutil"
 	"log"
 	"math"
-	"net"
 	"sync"
 	"time"
 )
-var (
-	tls = flag.Bool("tls", false, "If set true, uses TLS, else use TCP")
-	certFile = flag.String("cert_file", "", "TLS cert file")
-	keyFile = flag.String("key_file", "", "TLS key file")
-	jsonDBFile = flag.String("json_db_file","", "")
-	port = flag.Int("port", 10000, "Server port")
-)
+
 type routeGuideServer struct {
+	store Store
+
+	mu            sync.Mutex
 	savedFeatures []*pb.Feature
-	mu	sync.Mutex
-	routeNotes map[string][]*pb.RouteNote
+
+	pointIndex FeatureIndex
+	rangeIndex FeatureIndex
+
+	chat *chatHub
 }
 
 //	type RouteGuideServer interface
@@ -36,23 +31,30 @@ type routeGuideServer struct {
 //	RouteChat(RouteGuide_RouteChatServer) error
 //
 
+// indices returns the current point/range indices. Reading them under s.mu
+// keeps GetFeature/ListFeatures/RecordRoute safe against a concurrent
+// AddFeature/DeleteFeature rebuilding both indices.
+func (s *routeGuideServer) indices() (FeatureIndex, FeatureIndex) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pointIndex, s.rangeIndex
+}
+
 // Simple RPC
 func (s *routeGuideServer) GetFeature(ctx context.Context, point *pb.Point) (*pb.Feature, error) {
-	for _, feature := range s.savedFeatures {
-		if proto.Equal(feature.Location, point) {
-			return feature, nil
-		}
+	pointIndex, _ := s.indices()
+	if feature := pointIndex.Lookup(point); feature != nil {
+		return feature, nil
 	}
 	return &pb.Feature{Location: point}, nil
 }
 
 // Server-side streaming RPC
 func (s *routeGuideServer) ListFeatures(rect *pb.Rectangle, stream pb.RouteGuide_ListFeaturesServer) error {
-	for _, feature := range s.savedFeatures {
-		if inRange(feature.Location, rect) {
-			if err := stream.Send(feature); err != nil {
-				return err
-			}
+	_, rangeIndex := s.indices()
+	for _, feature := range rangeIndex.Range(rect) {
+		if err := stream.Send(feature); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -77,10 +79,9 @@ func (s *routeGuideServer) RecordRoute(stream pb.RouteGuide_RecordRouteServer) e
 			return err
 		}
 		pointCount += 1
-		for _, feature := range s.savedFeatures {
-			if proto.Equal(feature.Location, point) {
-				featureCount += 1
-			}
+		pointIndex, _ := s.indices()
+		if pointIndex.Lookup(point) != nil {
+			featureCount += 1
 		}
 		if lastPoint != nil {
 			distance += calcDistance(lastPoint, point)
@@ -90,22 +91,86 @@ func (s *routeGuideServer) RecordRoute(stream pb.RouteGuide_RecordRouteServer) e
 	}
 }
 
-func (s *routeGuideServer) RouteChat(stream pb.RouteGuide_RouteChatServer) error{
-	for {
-		in, err := stream.Recv()
-		if err == io.EOF {
-			return nil
+// RouteChat is a bidirectional streaming RPC. Unlike a simple echo, it
+// subscribes the caller to every location it sends a note at, via s.chat,
+// so notes are fanned out to every other client chatting at that point.
+func (s *routeGuideServer) RouteChat(stream pb.RouteGuide_RouteChatServer) error {
+	ctx := stream.Context()
+
+	var mu sync.Mutex
+	subs := make(map[pointKey]func())
+	merged := make(chan *pb.RouteNote, chatSubscriberBuffer)
+	closed := make(chan struct{})
+	defer close(closed)
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, cancel := range subs {
+			cancel()
 		}
-		if err != nil {
-			return err
+	}()
+
+	subscribe := func(point *pb.Point) {
+		key := keyFor(point)
+		mu.Lock()
+		if _, ok := subs[key]; ok {
+			mu.Unlock()
+			return
+		}
+		history, ch, cancel := s.chat.SubscribeWithHistory(point)
+		subs[key] = cancel
+		mu.Unlock()
+
+		for _, note := range history {
+			select {
+			case merged <- note:
+			case <-closed:
+				return
+			}
+		}
+		go func() {
+			for {
+				select {
+				case note, ok := <-ch:
+					if !ok {
+						return
+					}
+					select {
+					case merged <- note:
+					case <-closed:
+						return
+					}
+				case <-closed:
+					return
+				}
+			}
+		}()
+	}
+
+	recvDone := make(chan error, 1)
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err == io.EOF {
+				recvDone <- nil
+				return
+			}
+			if err != nil {
+				recvDone <- err
+				return
+			}
+			subscribe(in.Location)
+			s.chat.Publish(in)
 		}
-		key := serialize(in.Location)
-		s.mu.Lock()
-		s.routeNotes[key] = append(s.routeNotes[key],in)
-		rn := make([]*pb.RouteNote, len(s.routeNotes[key]))
-		copy(rn, s.routeNotes[key])
-		s.mu.Unlock()
-		for _, note := range rn {
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-recvDone:
+			return err
+		case note := <-merged:
 			if err := stream.Send(note); err != nil {
 				return err
 			}
@@ -113,18 +178,57 @@ func (s *routeGuideServer) RouteChat(stream pb.RouteGuide_RouteChatServer) error
 	}
 }
 
-func (s *routeGuideServer) loadFeatures(filePath string) {
-	var data [] byte
-	if filePath != "" {
-		var err error
-		data, err = ioutil.ReadFile(filePath)
-		if err != nil {
-			log.Fatalf("Failed to load default features : %v", err)
-		}
-		if err := json.Unmarshal(data, &s.savedFeatures); err != nil {
-			log.Fatalf("Failed to load default features: %v", err)
+func (s *routeGuideServer) loadFeatures() {
+	features, err := s.store.LoadFeatures()
+	if err != nil {
+		log.Fatalf("Failed to load default features : %v", err)
+	}
+	s.savedFeatures = features
+	s.pointIndex = newGeohashIndex(s.savedFeatures)
+	s.rangeIndex = newRTree(s.savedFeatures)
+}
+
+// AddFeature and DeleteFeature are a deliberate scope-down from the original
+// ask for "write RPCs": the service definition routeGuideServer implements
+// comes from the external, un-vendored google.golang.org/grpc/examples/route_guide/routeguide
+// package, so adding real AddFeature/DeleteFeature RPCs means regenerating
+// that package's pb.go from an extended .proto, which needs a protoc
+// toolchain this environment doesn't have and a proto source this repo
+// doesn't own. Rather than ship a half-finished or hand-faked generated
+// stub, these are plain Go methods on routeGuideServer: they update the
+// Store and the in-memory indices, but no gRPC client can call them yet.
+// Promoting them to real RPCs is a follow-up that needs sign-off on
+// vendoring/forking the proto package, not something this change decides
+// unilaterally.
+func (s *routeGuideServer) AddFeature(feature *pb.Feature) error {
+	if err := s.store.AddFeature(feature); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.savedFeatures = append(s.savedFeatures, feature)
+	s.pointIndex = newGeohashIndex(s.savedFeatures)
+	s.rangeIndex = newRTree(s.savedFeatures)
+	return nil
+}
+
+// DeleteFeature removes the feature at loc. See AddFeature's doc comment for
+// why this is a library method rather than an RPC for now.
+func (s *routeGuideServer) DeleteFeature(loc *pb.Point) error {
+	if err := s.store.DeleteFeature(loc); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.savedFeatures {
+		if proto.Equal(f.Location, loc) {
+			s.savedFeatures = append(s.savedFeatures[:i], s.savedFeatures[i+1:]...)
+			break
 		}
 	}
+	s.pointIndex = newGeohashIndex(s.savedFeatures)
+	s.rangeIndex = newRTree(s.savedFeatures)
+	return nil
 }
 
 func inRange(point *pb.Point, rect *pb.Rectangle) bool {
@@ -164,30 +268,32 @@ func calcDistance(p1 *pb.Point, p2 *pb.Point) int32 {
 }
 
 func serialize(point *pb.Point) string {
-	return fmt.Sprint("%d %d", point.Latitude, point.Longitude)
+	return fmt.Sprintf("%d %d", point.Latitude, point.Longitude)
 }
 
 func toRadians(num float64) float64 {
 	return num * math.Pi / float64(180)
 }
 
-func newServer() *routeGuideServer {
-	s := &routeGuideServer {routeNotes: make(map[string][]*pb.RouteNote)}
-	s.loadFeatures(*jsonDBFile)
-	return s
-}
-
-func main() {
-	flag.Parse()
-	lis ,err := net.Listen("tcp", fmt.Sprintf("localhost:%d", *port))
-	if err != nil {
-		log.Fatalf("failed to listen : %v", err)
-	}
-	var opts []grpc.ServerOption
-	if *tls {
-
+// NewStore builds the Store for the given -store_backend value ("json" or
+// "bolt", "json" is the default if backend is empty). jsonDBFile and
+// boltDBFile are the corresponding backend's data file path, used only by
+// the matching backend.
+func NewStore(backend, jsonDBFile, boltDBFile string) (Store, error) {
+	switch backend {
+	case "json", "":
+		return newJSONFileStore(jsonDBFile), nil
+	case "bolt":
+		return newBoltStore(boltDBFile)
+	default:
+		return nil, fmt.Errorf("unknown -store_backend %q (want \"json\" or \"bolt\")", backend)
 	}
-	grpcServer := grpc.NewServer(opts ...)
-	pb.RegisterRouteGuideServer(grpcServer, newServer().Svc())
+}
 
+// NewServer builds a routeGuideServer backed by store, loading its initial
+// features and route-note history.
+func NewServer(store Store) pb.RouteGuideServer {
+	s := &routeGuideServer{store: store, chat: newChatHub(store)}
+	s.loadFeatures()
+	return s
 }
\ No newline at end of file
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+// Store is the persistence boundary for routeGuideServer. NewServer takes a
+// Store so tests can inject an in-memory implementation instead of touching
+// the filesystem, and so the on-disk format can evolve independently of the
+// RPC layer.
+type Store interface {
+	// LoadFeatures returns every feature currently persisted.
+	LoadFeatures() ([]*pb.Feature, error)
+	// AddFeature persists a new feature. It returns an error if a feature
+	// already exists at the same location.
+	AddFeature(feature *pb.Feature) error
+	// DeleteFeature removes the feature at loc, if any.
+	DeleteFeature(loc *pb.Point) error
+
+	// LoadRouteNotes returns every route note ever appended, grouped by the
+	// location it was sent at, so a freshly started server (and late
+	// RouteChat subscribers) can replay history.
+	LoadRouteNotes() (map[pointKey][]*pb.RouteNote, error)
+	// AppendRouteNote durably records note.
+	AppendRouteNote(note *pb.RouteNote) error
+}
+
+// jsonFileStore keeps features in a single JSON file (the existing
+// -json_db_file format) and route notes in an adjacent append-only
+// newline-delimited JSON log. Feature writes are atomic: the new contents
+// are written to a temp file and renamed over the original, so a crash
+// mid-write can never leave a half-written database on disk.
+type jsonFileStore struct {
+	mu        sync.Mutex
+	path      string
+	notesPath string
+	features  []*pb.Feature
+}
+
+func newJSONFileStore(path string) *jsonFileStore {
+	return &jsonFileStore{path: path, notesPath: notesLogPath(path)}
+}
+
+func notesLogPath(featuresPath string) string {
+	if featuresPath == "" {
+		return ""
+	}
+	ext := filepath.Ext(featuresPath)
+	return strings.TrimSuffix(featuresPath, ext) + ".notes.jsonl"
+}
+
+func (s *jsonFileStore) LoadFeatures() ([]*pb.Feature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.path, err)
+	}
+	var features []*pb.Feature
+	if err := json.Unmarshal(data, &features); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", s.path, err)
+	}
+	s.features = features
+	return features, nil
+}
+
+func (s *jsonFileStore) AddFeature(feature *pb.Feature) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.features {
+		if proto.Equal(f.Location, feature.Location) {
+			return fmt.Errorf("feature already exists at %v", feature.Location)
+		}
+	}
+	s.features = append(s.features, feature)
+	return s.rewriteLocked()
+}
+
+func (s *jsonFileStore) DeleteFeature(loc *pb.Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.features {
+		if proto.Equal(f.Location, loc) {
+			s.features = append(s.features[:i], s.features[i+1:]...)
+			return s.rewriteLocked()
+		}
+	}
+	return fmt.Errorf("no feature at %v", loc)
+}
+
+// rewriteLocked atomically rewrites the backing file with s.features.
+// Callers must hold s.mu.
+func (s *jsonFileStore) rewriteLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.features, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *jsonFileStore) LoadRouteNotes() (map[pointKey][]*pb.RouteNote, error) {
+	out := make(map[pointKey][]*pb.RouteNote)
+	if s.notesPath == "" {
+		return out, nil
+	}
+	f, err := os.Open(s.notesPath)
+	if os.IsNotExist(err) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for {
+		var note pb.RouteNote
+		if err := dec.Decode(&note); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		key := keyFor(note.Location)
+		out[key] = append(out[key], &note)
+	}
+	return out, nil
+}
+
+func (s *jsonFileStore) AppendRouteNote(note *pb.RouteNote) error {
+	if s.notesPath == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.notesPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(note)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = f.Write(data)
+	return err
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	featuresBucket   = []byte("features")
+	routeNotesBucket = []byte("route_notes")
+)
+
+// boltStore persists features and route notes in a single BoltDB file.
+// Features are keyed by geohash so a future range-scoped load doesn't need
+// to touch unrelated keys, and route notes are grouped per geohash bucket
+// as a list that each append reads, extends and writes back, instead of
+// jsonFileStore's single append-only log shared by all locations in a
+// second file on disk.
+type boltStore struct {
+	db               *bolt.DB
+	geohashPrecision int
+}
+
+func newBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open bolt store %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(featuresBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(routeNotesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStore{db: db, geohashPrecision: defaultGeohashPrecision}, nil
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *boltStore) featureKey(loc *pb.Point) []byte {
+	return []byte(geohashEncode(loc, s.geohashPrecision) + "|" + serialize(loc))
+}
+
+func (s *boltStore) LoadFeatures() ([]*pb.Feature, error) {
+	var features []*pb.Feature
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(featuresBucket).ForEach(func(_, v []byte) error {
+			var f pb.Feature
+			if err := json.Unmarshal(v, &f); err != nil {
+				return err
+			}
+			features = append(features, &f)
+			return nil
+		})
+	})
+	return features, err
+}
+
+func (s *boltStore) AddFeature(feature *pb.Feature) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(featuresBucket)
+		key := s.featureKey(feature.Location)
+		if b.Get(key) != nil {
+			return fmt.Errorf("feature already exists at %v", feature.Location)
+		}
+		data, err := json.Marshal(feature)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
+	})
+}
+
+func (s *boltStore) DeleteFeature(loc *pb.Point) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(featuresBucket)
+		key := s.featureKey(loc)
+		if b.Get(key) == nil {
+			return fmt.Errorf("no feature at %v", loc)
+		}
+		return b.Delete(key)
+	})
+}
+
+func (s *boltStore) LoadRouteNotes() (map[pointKey][]*pb.RouteNote, error) {
+	out := make(map[pointKey][]*pb.RouteNote)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(routeNotesBucket).ForEach(func(_, v []byte) error {
+			var entries []*pb.RouteNote
+			if err := json.Unmarshal(v, &entries); err != nil {
+				return err
+			}
+			for _, n := range entries {
+				out[keyFor(n.Location)] = append(out[keyFor(n.Location)], n)
+			}
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (s *boltStore) AppendRouteNote(note *pb.RouteNote) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(routeNotesBucket)
+		key := []byte(geohashEncode(note.Location, s.geohashPrecision))
+		var entries []*pb.RouteNote
+		if data := b.Get(key); data != nil {
+			if err := json.Unmarshal(data, &entries); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, note)
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
+	})
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+func newTempBoltStore(t *testing.T) *boltStore {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := newBoltStore(filepath.Join(dir, "db.bolt"))
+	if err != nil {
+		t.Fatalf("newBoltStore: %v", err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+	})
+	return store
+}
+
+func TestBoltStoreAddAndDeleteFeature(t *testing.T) {
+	store := newTempBoltStore(t)
+	if features, err := store.LoadFeatures(); err != nil || len(features) != 0 {
+		t.Fatalf("LoadFeatures = %v, %v, want empty, nil", features, err)
+	}
+
+	f := &pb.Feature{Name: "test", Location: point(1, 2)}
+	if err := store.AddFeature(f); err != nil {
+		t.Fatalf("AddFeature: %v", err)
+	}
+	if err := store.AddFeature(f); err == nil {
+		t.Fatal("AddFeature: expected error for duplicate location")
+	}
+
+	features, err := store.LoadFeatures()
+	if err != nil {
+		t.Fatalf("LoadFeatures: %v", err)
+	}
+	if len(features) != 1 || features[0].Name != "test" {
+		t.Fatalf("unexpected stored features: %+v", features)
+	}
+
+	if err := store.DeleteFeature(f.Location); err != nil {
+		t.Fatalf("DeleteFeature: %v", err)
+	}
+	if err := store.DeleteFeature(f.Location); err == nil {
+		t.Fatal("DeleteFeature: expected error for missing location")
+	}
+}
+
+func TestBoltStoreRouteNoteRoundTrip(t *testing.T) {
+	store := newTempBoltStore(t)
+	p := point(5, 6)
+
+	if err := store.AppendRouteNote(&pb.RouteNote{Location: p, Message: "one"}); err != nil {
+		t.Fatalf("AppendRouteNote: %v", err)
+	}
+	if err := store.AppendRouteNote(&pb.RouteNote{Location: p, Message: "two"}); err != nil {
+		t.Fatalf("AppendRouteNote: %v", err)
+	}
+
+	notes, err := store.LoadRouteNotes()
+	if err != nil {
+		t.Fatalf("LoadRouteNotes: %v", err)
+	}
+	got := notes[keyFor(p)]
+	if len(got) != 2 || got[0].Message != "one" || got[1].Message != "two" {
+		t.Fatalf("unexpected notes: %+v", got)
+	}
+}
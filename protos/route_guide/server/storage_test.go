@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "google.golang.org/grpc/examples/route_guide/routeguide"
+)
+
+func newTempJSONFileStore(t *testing.T) *jsonFileStore {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.json")
+	if err := ioutil.WriteFile(path, []byte("[]"), 0644); err != nil {
+		t.Fatalf("seeding %s: %v", path, err)
+	}
+	return newJSONFileStore(path)
+}
+
+func TestJSONFileStoreAddAndDeleteFeature(t *testing.T) {
+	store := newTempJSONFileStore(t)
+	if _, err := store.LoadFeatures(); err != nil {
+		t.Fatalf("LoadFeatures: %v", err)
+	}
+
+	f := &pb.Feature{Name: "test", Location: point(1, 2)}
+	if err := store.AddFeature(f); err != nil {
+		t.Fatalf("AddFeature: %v", err)
+	}
+	if err := store.AddFeature(f); err == nil {
+		t.Fatal("AddFeature: expected error for duplicate location")
+	}
+
+	data, err := ioutil.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("reading backing file: %v", err)
+	}
+	var onDisk []*pb.Feature
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("unmarshal backing file: %v", err)
+	}
+	if len(onDisk) != 1 || onDisk[0].Name != "test" {
+		t.Fatalf("unexpected on-disk features: %+v", onDisk)
+	}
+
+	if err := store.DeleteFeature(f.Location); err != nil {
+		t.Fatalf("DeleteFeature: %v", err)
+	}
+	if err := store.DeleteFeature(f.Location); err == nil {
+		t.Fatal("DeleteFeature: expected error for missing location")
+	}
+}
+
+func TestJSONFileStoreRouteNoteRoundTrip(t *testing.T) {
+	store := newTempJSONFileStore(t)
+	p := point(5, 6)
+
+	if err := store.AppendRouteNote(&pb.RouteNote{Location: p, Message: "one"}); err != nil {
+		t.Fatalf("AppendRouteNote: %v", err)
+	}
+	if err := store.AppendRouteNote(&pb.RouteNote{Location: p, Message: "two"}); err != nil {
+		t.Fatalf("AppendRouteNote: %v", err)
+	}
+
+	notes, err := store.LoadRouteNotes()
+	if err != nil {
+		t.Fatalf("LoadRouteNotes: %v", err)
+	}
+	got := notes[keyFor(p)]
+	if len(got) != 2 || got[0].Message != "one" || got[1].Message != "two" {
+		t.Fatalf("unexpected notes: %+v", got)
+	}
+
+	if _, err := os.Stat(store.notesPath); err != nil {
+		t.Fatalf("expected notes log at %s: %v", store.notesPath, err)
+	}
+}